@@ -30,15 +30,57 @@ func ResourceChange(
 	schema *configschema.Block,
 	color *colorstring.Colorize,
 ) string {
+	color = normalizeColor(color)
+
 	var buf bytes.Buffer
+	buf.WriteString(resourceChangeHeader(addr, change, color))
 
-	if color == nil {
-		color = &colorstring.Colorize{
-			Colors:  colorstring.DefaultColors,
-			Disable: true,
-			Reset:   false,
-		}
+	p := blockBodyDiffPrinter{
+		buf:    &buf,
+		color:  color,
+		action: change.Action,
 	}
+	p.writeBlockBodyDiff(schema, change.Old, change.New, 6)
+
+	buf.WriteString("    }\n")
+
+	return buf.String()
+}
+
+// ResourceChangeHeader returns just the one-line header that ResourceChange
+// would otherwise include as the first line of its result: the resource
+// address, the action symbol, and (where applicable) an annotation such as
+// "# new resource required".
+//
+// This is useful for callers that want to stream progress output, showing
+// each resource's header as soon as its action is known and filling in the
+// body (the rest of what ResourceChange would produce) lazily afterwards.
+//
+// If "color" is non-nil, it will be used to color the result. Otherwise,
+// no color codes will be included.
+func ResourceChangeHeader(addr *terraform.ResourceAddress, change *diffs.Change, color *colorstring.Colorize) string {
+	return resourceChangeHeader(addr, change, normalizeColor(color))
+}
+
+// normalizeColor returns color unchanged if it is non-nil, or otherwise a
+// Colorize that strips all color codes from its input. Colorize is not
+// safe to use directly without this normalization, since a nil *Colorize
+// would panic when asked to color a string.
+func normalizeColor(color *colorstring.Colorize) *colorstring.Colorize {
+	if color != nil {
+		return color
+	}
+	return &colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Disable: true,
+		Reset:   false,
+	}
+}
+
+// resourceChangeHeader assumes that color has already been normalized with
+// normalizeColor, unlike the exported functions that wrap it.
+func resourceChangeHeader(addr *terraform.ResourceAddress, change *diffs.Change, color *colorstring.Colorize) string {
+	var buf bytes.Buffer
 
 	buf.WriteString(color.Color("[reset]"))
 
@@ -53,6 +95,8 @@ func ResourceChange(
 		buf.WriteString(color.Color("[red]-[reset]/[green]+[reset] "))
 	case diffs.Delete:
 		buf.WriteString(color.Color("[red]  -[reset] "))
+	case diffs.Refresh:
+		buf.WriteString(color.Color("[magenta] ~>[reset] "))
 	default:
 		// should never happen, since the above is exhaustive
 		buf.WriteString(color.Color("??? "))
@@ -76,25 +120,28 @@ func ResourceChange(
 		buf.WriteString(addr.String())
 	}
 
-	if change.Action == diffs.Replace {
+	switch change.Action {
+	case diffs.Replace:
 		buf.WriteString(" [bold]# new resource required[bold]")
+	case diffs.Refresh:
+		buf.WriteString(color.Color(" [bold]# refreshed from real infrastructure[reset]"))
 	}
 	buf.WriteString("\n")
 
-	p := blockBodyDiffPrinter{
-		buf:   &buf,
-		color: color,
-	}
-	p.writeBlockBodyDiff(schema, change.Old, change.New, 6)
-
-	buf.WriteString("    }\n")
-
 	return buf.String()
 }
 
 type blockBodyDiffPrinter struct {
 	buf   *bytes.Buffer
 	color *colorstring.Colorize
+
+	// action is the action of the change being rendered. Most of the
+	// printer's behavior doesn't vary by action, but a diffs.Refresh
+	// change compares a prior state value to a value read directly from
+	// the provider rather than to a planned configuration, so callers
+	// must avoid any future input-oriented annotations (such as "will be
+	// changed" or force-new markers) that wouldn't make sense here.
+	action diffs.Action
 }
 
 func (p *blockBodyDiffPrinter) writeBlockBodyDiff(schema *configschema.Block, old, new cty.Value, indent int) {
@@ -128,7 +175,218 @@ func (p *blockBodyDiffPrinter) writeBlockBodyDiff(schema *configschema.Block, ol
 		}
 	}
 
-	// TODO: Nested blocks
+	p.writeNestedBlockDiffs(schema, old, new, indent)
+}
+
+// writeNestedBlockDiffs handles the portion of writeBlockBodyDiff that deals
+// with configschema.NestedBlock, which the attribute pass of
+// writeBlockBodyDiff skips because attributes and nested blocks have different enough shapes that
+// they are easiest to deal with in two separate passes.
+func (p *blockBodyDiffPrinter) writeNestedBlockDiffs(schema *configschema.Block, old, new cty.Value, indent int) {
+	if len(schema.BlockTypes) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(schema.BlockTypes))
+	for name := range schema.BlockTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		blockS := schema.BlockTypes[name]
+		oldVal := ctyGetAttrMaybeNull(old, name)
+		newVal := ctyGetAttrMaybeNull(new, name)
+		if oldVal.RawEquals(newVal) {
+			// Skip blocks that have no change at all, the same as we do
+			// for attributes above.
+			continue
+		}
+
+		switch blockS.Nesting {
+		case configschema.NestingSingle, configschema.NestingGroup:
+			p.writeNestedBlockDiff(name, &blockS.Block, oldVal, newVal, indent)
+		case configschema.NestingList:
+			p.writeNestedBlockDiffSequence(name, &blockS.Block, oldVal, newVal, indent)
+		case configschema.NestingMap:
+			p.writeNestedBlockDiffMap(name, &blockS.Block, oldVal, newVal, indent)
+		case configschema.NestingSet:
+			p.writeNestedBlockDiffSet(name, &blockS.Block, oldVal, newVal, indent)
+		default:
+			// should never happen, since the above is exhaustive for the
+			// nesting modes defined by the configschema package
+		}
+	}
+}
+
+// writeNestedBlockDiff writes a single instance of a nested block, recursing
+// into writeBlockBodyDiff for its body so that arbitrarily deep schemas are
+// supported.
+func (p *blockBodyDiffPrinter) writeNestedBlockDiff(name string, schema *configschema.Block, old, new cty.Value, indent int) {
+	p.buf.WriteString(strings.Repeat(" ", indent))
+	switch {
+	case old.IsNull():
+		p.buf.WriteString(p.color.Color("[green]+[reset] "))
+	case new.IsNull():
+		p.buf.WriteString(p.color.Color("[red]-[reset] "))
+	default:
+		p.buf.WriteString(p.color.Color("[yellow]~[reset] "))
+	}
+
+	p.buf.WriteString(p.color.Color("[bold]"))
+	p.buf.WriteString(name)
+	p.buf.WriteString(p.color.Color("[reset]"))
+	p.buf.WriteString(" {\n")
+
+	p.writeBlockBodyDiff(schema, old, new, indent+4)
+
+	p.buf.WriteString(strings.Repeat(" ", indent))
+	p.buf.WriteString("}\n")
+}
+
+// writeNestedBlockDiffSequence handles NestingList, correlating old and new
+// child blocks by their index and rendering one nested block per index that
+// differs between old and new.
+func (p *blockBodyDiffPrinter) writeNestedBlockDiffSequence(name string, schema *configschema.Block, old, new cty.Value, indent int) {
+	oldItems := ctyCollectionElements(old)
+	newItems := ctyCollectionElements(new)
+
+	for i := 0; i < len(oldItems) || i < len(newItems); i++ {
+		oldItem := ctyNullBlockVal(schema)
+		if i < len(oldItems) {
+			oldItem = oldItems[i]
+		}
+		newItem := ctyNullBlockVal(schema)
+		if i < len(newItems) {
+			newItem = newItems[i]
+		}
+		if oldItem.RawEquals(newItem) {
+			continue
+		}
+
+		p.writeNestedBlockDiff(name, schema, oldItem, newItem, indent)
+	}
+}
+
+// writeNestedBlockDiffMap handles NestingMap, correlating old and new child
+// blocks by their map key and rendering one nested block per key that is
+// added, removed, or has a differing value.
+func (p *blockBodyDiffPrinter) writeNestedBlockDiffMap(name string, schema *configschema.Block, old, new cty.Value, indent int) {
+	oldItems := ctyCollectionElementsByKey(old)
+	newItems := ctyCollectionElementsByKey(new)
+
+	keys := make(map[string]struct{})
+	for k := range oldItems {
+		keys[k] = struct{}{}
+	}
+	for k := range newItems {
+		keys[k] = struct{}{}
+	}
+	keyNames := make([]string, 0, len(keys))
+	for k := range keys {
+		keyNames = append(keyNames, k)
+	}
+	sort.Strings(keyNames)
+
+	for _, k := range keyNames {
+		oldItem, ok := oldItems[k]
+		if !ok {
+			oldItem = ctyNullBlockVal(schema)
+		}
+		newItem, ok := newItems[k]
+		if !ok {
+			newItem = ctyNullBlockVal(schema)
+		}
+		if oldItem.RawEquals(newItem) {
+			continue
+		}
+
+		p.writeNestedBlockDiff(fmt.Sprintf("%s %q", name, k), schema, oldItem, newItem, indent)
+	}
+}
+
+// writeNestedBlockDiffSet handles NestingSet, which (like the set handling
+// in writeValueDiff) can only correlate old and new child blocks by their
+// entire value, since set elements have no other identity. Elements present
+// in both old and new are therefore always identical and need no further
+// diffing; only added and removed elements are printed, each with its full
+// body rendered as entirely new or entirely gone.
+func (p *blockBodyDiffPrinter) writeNestedBlockDiffSet(name string, schema *configschema.Block, old, new cty.Value, indent int) {
+	var oldItems, newItems []cty.Value
+	if old.IsKnown() && !old.IsNull() {
+		for it := old.ElementIterator(); it.Next(); {
+			_, val := it.Element()
+			oldItems = append(oldItems, val)
+		}
+	}
+	if new.IsKnown() && !new.IsNull() {
+		for it := new.ElementIterator(); it.Next(); {
+			_, val := it.Element()
+			newItems = append(newItems, val)
+		}
+	}
+
+	null := ctyNullBlockVal(schema)
+
+	for _, oldItem := range oldItems {
+		found := false
+		for _, newItem := range newItems {
+			if oldItem.RawEquals(newItem) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.writeNestedBlockDiff(name, schema, oldItem, null, indent)
+		}
+	}
+	for _, newItem := range newItems {
+		found := false
+		for _, oldItem := range oldItems {
+			if oldItem.RawEquals(newItem) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.writeNestedBlockDiff(name, schema, null, newItem, indent)
+		}
+	}
+}
+
+// ctyCollectionElements returns the elements of a list or tuple value in
+// order, or nil if the value is null or unknown.
+func ctyCollectionElements(val cty.Value) []cty.Value {
+	if !val.IsKnown() || val.IsNull() {
+		return nil
+	}
+	var ret []cty.Value
+	for it := val.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+// ctyCollectionElementsByKey returns the elements of a map value keyed by
+// their string keys, or an empty map if the value is null or unknown.
+func ctyCollectionElementsByKey(val cty.Value) map[string]cty.Value {
+	ret := make(map[string]cty.Value)
+	if !val.IsKnown() || val.IsNull() {
+		return ret
+	}
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		ret[k.AsString()] = v
+	}
+	return ret
+}
+
+// ctyNullBlockVal returns a null value of the object type implied by the
+// given nested block schema, for use as a stand-in when one side of a
+// correlated pair of child blocks is absent.
+func ctyNullBlockVal(schema *configschema.Block) cty.Value {
+	return cty.NullVal(schema.ImpliedType())
 }
 
 func (p *blockBodyDiffPrinter) writeAttrDiff(name string, attrS *configschema.Attribute, old, new cty.Value, nameLen, indent int) {
@@ -167,6 +425,13 @@ func (p *blockBodyDiffPrinter) writeAttrDiff(name string, attrS *configschema.At
 		}
 	}
 
+	if p.action == diffs.Refresh {
+		// A refresh change didn't come from configuration, so rather than
+		// framing it as something that "will be changed" we note that the
+		// value has drifted from what was last recorded in state.
+		p.buf.WriteString(p.color.Color(" [dark_gray]# drifted from state[reset]"))
+	}
+
 	p.buf.WriteString("\n")
 
 }
@@ -272,9 +537,126 @@ func (p *blockBodyDiffPrinter) writeValueDiff(old, new cty.Value, indent int) {
 	// values are known and non-null.
 	if old.IsKnown() && new.IsKnown() && !old.IsNull() && !new.IsNull() {
 		switch {
-		// TODO: list diffs using longest-common-subsequence matching algorithm
-		// TODO: map diffs showing changes on a per-key basis
-		// TODO: multi-line string diffs showing lines added/removed using longest-common-subsequence
+		case ty.IsMapType():
+			p.buf.WriteString("{\n")
+
+			oldElems := ctyCollectionElementsByKey(old)
+			newElems := ctyCollectionElementsByKey(new)
+
+			keySet := make(map[string]struct{}, len(oldElems)+len(newElems))
+			for k := range oldElems {
+				keySet[k] = struct{}{}
+			}
+			for k := range newElems {
+				keySet[k] = struct{}{}
+			}
+			keys := make([]string, 0, len(keySet))
+			for k := range keySet {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				oldVal, oldHas := oldElems[k]
+				newVal, newHas := newElems[k]
+
+				unchanged := oldHas && newHas && oldVal.RawEquals(newVal)
+
+				p.buf.WriteString(strings.Repeat(" ", indent+2))
+				switch {
+				case !oldHas:
+					p.buf.WriteString(p.color.Color("[green]+[reset] "))
+				case !newHas:
+					p.buf.WriteString(p.color.Color("[red]-[reset] "))
+				case unchanged:
+					p.buf.WriteString("  ")
+				default:
+					p.buf.WriteString(p.color.Color("[yellow]~[reset] "))
+				}
+				fmt.Fprintf(p.buf, "%q = ", k)
+
+				switch {
+				case !oldHas:
+					p.writeValue(newVal, indent+4)
+				case !newHas:
+					p.writeValue(oldVal, indent+4)
+				case oldVal.RawEquals(newVal):
+					p.writeValue(oldVal, indent+4)
+				default:
+					p.writeValueDiff(oldVal, newVal, indent+4)
+				}
+				p.buf.WriteString("\n")
+			}
+
+			p.buf.WriteString(strings.Repeat(" ", indent))
+			p.buf.WriteString("}")
+			return
+
+		case ty.IsListType() || ty.IsTupleType():
+			var oldItems, newItems []cty.Value
+			for it := old.ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				oldItems = append(oldItems, v)
+			}
+			for it := new.ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				newItems = append(newItems, v)
+			}
+
+			comparable := true
+			for _, v := range oldItems {
+				if !v.IsWhollyKnown() {
+					comparable = false
+					break
+				}
+			}
+			if comparable {
+				for _, v := range newItems {
+					if !v.IsWhollyKnown() {
+						comparable = false
+						break
+					}
+				}
+			}
+			if !comparable {
+				// If any element isn't fully known then we can't meaningfully
+				// compare it for equality against other elements, so we'll
+				// fall back to showing the whole old and new values below.
+				break
+			}
+
+			p.buf.WriteString("[\n")
+
+			lcs := diffs.LongestCommonSubsequence(oldItems, newItems)
+			var oldI, newI, lcsI int
+			for oldI < len(oldItems) || newI < len(newItems) || lcsI < len(lcs) {
+				for oldI < len(oldItems) && (lcsI >= len(lcs) || !oldItems[oldI].RawEquals(lcs[lcsI])) {
+					p.buf.WriteString(strings.Repeat(" ", indent+2))
+					p.buf.WriteString(p.color.Color("[red]-[reset] "))
+					p.writeValue(oldItems[oldI], indent+4)
+					p.buf.WriteString(",\n")
+					oldI++
+				}
+				for newI < len(newItems) && (lcsI >= len(lcs) || !newItems[newI].RawEquals(lcs[lcsI])) {
+					p.buf.WriteString(strings.Repeat(" ", indent+2))
+					p.buf.WriteString(p.color.Color("[green]+[reset] "))
+					p.writeValue(newItems[newI], indent+4)
+					p.buf.WriteString(",\n")
+					newI++
+				}
+				if lcsI < len(lcs) {
+					p.buf.WriteString(strings.Repeat(" ", indent+4)) // +4 here because there's no symbol
+					p.writeValue(lcs[lcsI], indent+4)
+					p.buf.WriteString(",\n")
+					lcsI++
+					oldI++
+					newI++
+				}
+			}
+
+			p.buf.WriteString(strings.Repeat(" ", indent))
+			p.buf.WriteString("]")
+			return
 
 		case ty == cty.String:
 			// We only have special behavior for multi-line strings here
@@ -0,0 +1,356 @@
+package format
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/diffs"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// jsonFormatVersion is embedded in every document produced by
+// ResourceChangeJSON and PlanJSON, so that consumers can tell which shape
+// of document they're looking at and detect schema changes made in later
+// versions of Terraform.
+const jsonFormatVersion = "0.1"
+
+// ChangeJSON is the machine-readable equivalent of the output produced by
+// ResourceChange: it describes the same change to a particular resource,
+// but as data rather than as a string meant for a terminal.
+type ChangeJSON struct {
+	FormatVersion string `json:"format_version"`
+	Address       string `json:"address"`
+	Action        string `json:"action"`
+
+	// ReplaceReason is set only when Action is "replace", and gives a
+	// human-readable explanation of why the resource instance must be
+	// replaced rather than updated in place.
+	ReplaceReason string `json:"replace_reason,omitempty"`
+
+	Attributes map[string]*AttributeChangeJSON `json:"attributes,omitempty"`
+	Blocks     map[string][]*BlockChangeJSON   `json:"blocks,omitempty"`
+}
+
+// BlockChangeJSON is the JSON representation of a change to a single
+// instance of a nested block, as found under ChangeJSON.Blocks.
+type BlockChangeJSON struct {
+	// Key identifies this particular instance of the block when its
+	// nesting mode gives child blocks a natural key, such as
+	// configschema.NestingMap. It is omitted for nesting modes that
+	// instead correlate children positionally or by their full value.
+	Key string `json:"key,omitempty"`
+
+	Action string `json:"action"`
+
+	Attributes map[string]*AttributeChangeJSON `json:"attributes,omitempty"`
+	Blocks     map[string][]*BlockChangeJSON   `json:"blocks,omitempty"`
+}
+
+// AttributeChangeJSON is the JSON representation of a change to a single
+// attribute's value, as found under ChangeJSON.Attributes and
+// BlockChangeJSON.Attributes.
+type AttributeChangeJSON struct {
+	Old json.RawMessage `json:"old,omitempty"`
+	New json.RawMessage `json:"new,omitempty"`
+
+	// Sensitive is true for an attribute whose schema marks it as
+	// sensitive. When true, Old and New are never populated, even if the
+	// underlying values are available to us, so that sensitive data
+	// cannot leak into logs or other tooling that consumes this format.
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// PlanDiffJSON is the machine-readable equivalent of a sequence of
+// ResourceChange calls: it aggregates many ChangeJSON values, one per
+// resource instance, that together make up a plan.
+type PlanDiffJSON struct {
+	FormatVersion   string        `json:"format_version"`
+	ResourceChanges []*ChangeJSON `json:"resource_changes"`
+}
+
+// ResourceChangeJSON returns a machine-readable representation of a change
+// to a particular resource, carrying the same information that
+// ResourceChange renders as text.
+//
+// The resource schema must be provided along with the change so that the
+// formatted change can reflect the configuration structure for the
+// associated resource, exactly as with ResourceChange.
+func ResourceChangeJSON(addr *terraform.ResourceAddress, change *diffs.Change, schema *configschema.Block) (*ChangeJSON, error) {
+	attrs, err := attributeChangesJSON(schema, change.Old, change.New)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := blockChangesJSON(schema, change.Old, change.New)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &ChangeJSON{
+		FormatVersion: jsonFormatVersion,
+		Address:       addr.String(),
+		Action:        change.Action.String(),
+		Attributes:    attrs,
+		Blocks:        blocks,
+	}
+	if change.Action == diffs.Replace {
+		// We don't yet track a more specific reason for any particular
+		// attribute requiring replacement, so for now this is the one
+		// reason we're able to report.
+		ret.ReplaceReason = "requires replacement"
+	}
+
+	return ret, nil
+}
+
+// PlanJSON aggregates the changes to several resource instances, previously
+// built with ResourceChangeJSON, into a single document describing a whole
+// plan.
+func PlanJSON(changes []*ChangeJSON) *PlanDiffJSON {
+	return &PlanDiffJSON{
+		FormatVersion:   jsonFormatVersion,
+		ResourceChanges: changes,
+	}
+}
+
+func attributeChangesJSON(schema *configschema.Block, old, new cty.Value) (map[string]*AttributeChangeJSON, error) {
+	if len(schema.Attributes) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string]*AttributeChangeJSON)
+	for name, attrS := range schema.Attributes {
+		oldVal := ctyGetAttrMaybeNull(old, name)
+		newVal := ctyGetAttrMaybeNull(new, name)
+		if oldVal.RawEquals(newVal) {
+			continue
+		}
+
+		ac, err := attributeChangeJSONFor(attrS, oldVal, newVal)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = ac
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return ret, nil
+}
+
+func attributeChangeJSONFor(attrS *configschema.Attribute, old, new cty.Value) (*AttributeChangeJSON, error) {
+	if attrS.Sensitive {
+		return &AttributeChangeJSON{Sensitive: true}, nil
+	}
+
+	oldJSON, err := ctyValueJSON(attrS.Type, old)
+	if err != nil {
+		return nil, err
+	}
+	newJSON, err := ctyValueJSON(attrS.Type, new)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttributeChangeJSON{Old: oldJSON, New: newJSON}, nil
+}
+
+// ctyValueJSON encodes a single cty value using the implied JSON mapping for
+// its type, with two special cases: unknown values are encoded as the
+// string "(not yet known)", the same sentinel used in the human-readable
+// renderer, and null values are encoded as JSON null. Neither of those
+// special cases can be produced by ctyjson.Marshal on its own, since JSON
+// has no way to represent "unknown" and cty's null handling isn't in terms
+// of the JSON null literal.
+func ctyValueJSON(ty cty.Type, val cty.Value) (json.RawMessage, error) {
+	if !val.IsKnown() {
+		return json.RawMessage(`"(not yet known)"`), nil
+	}
+	if val.IsNull() {
+		return json.RawMessage("null"), nil
+	}
+
+	raw, err := ctyjson.Marshal(val, ty)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(raw), nil
+}
+
+func blockChangesJSON(schema *configschema.Block, old, new cty.Value) (map[string][]*BlockChangeJSON, error) {
+	if len(schema.BlockTypes) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string][]*BlockChangeJSON)
+	for name, blockS := range schema.BlockTypes {
+		oldVal := ctyGetAttrMaybeNull(old, name)
+		newVal := ctyGetAttrMaybeNull(new, name)
+		if oldVal.RawEquals(newVal) {
+			continue
+		}
+
+		instances, err := nestedBlockInstancesJSON(&blockS.Block, blockS.Nesting, oldVal, newVal)
+		if err != nil {
+			return nil, err
+		}
+		if len(instances) > 0 {
+			ret[name] = instances
+		}
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return ret, nil
+}
+
+func nestedBlockInstancesJSON(schema *configschema.Block, nesting configschema.NestingMode, old, new cty.Value) ([]*BlockChangeJSON, error) {
+	var ret []*BlockChangeJSON
+
+	switch nesting {
+	case configschema.NestingSingle, configschema.NestingGroup:
+		bc, err := blockChangeJSONFor(schema, "", old, new)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, bc)
+
+	case configschema.NestingList:
+		oldItems := ctyCollectionElements(old)
+		newItems := ctyCollectionElements(new)
+		for i := 0; i < len(oldItems) || i < len(newItems); i++ {
+			oldItem := ctyNullBlockVal(schema)
+			if i < len(oldItems) {
+				oldItem = oldItems[i]
+			}
+			newItem := ctyNullBlockVal(schema)
+			if i < len(newItems) {
+				newItem = newItems[i]
+			}
+			if oldItem.RawEquals(newItem) {
+				continue
+			}
+
+			bc, err := blockChangeJSONFor(schema, "", oldItem, newItem)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, bc)
+		}
+
+	case configschema.NestingMap:
+		oldItems := ctyCollectionElementsByKey(old)
+		newItems := ctyCollectionElementsByKey(new)
+
+		keySet := make(map[string]struct{}, len(oldItems)+len(newItems))
+		for k := range oldItems {
+			keySet[k] = struct{}{}
+		}
+		for k := range newItems {
+			keySet[k] = struct{}{}
+		}
+		keys := make([]string, 0, len(keySet))
+		for k := range keySet {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			oldItem, ok := oldItems[k]
+			if !ok {
+				oldItem = ctyNullBlockVal(schema)
+			}
+			newItem, ok := newItems[k]
+			if !ok {
+				newItem = ctyNullBlockVal(schema)
+			}
+			if oldItem.RawEquals(newItem) {
+				continue
+			}
+
+			bc, err := blockChangeJSONFor(schema, k, oldItem, newItem)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, bc)
+		}
+
+	case configschema.NestingSet:
+		var oldItems, newItems []cty.Value
+		if old.IsKnown() && !old.IsNull() {
+			for it := old.ElementIterator(); it.Next(); {
+				_, val := it.Element()
+				oldItems = append(oldItems, val)
+			}
+		}
+		if new.IsKnown() && !new.IsNull() {
+			for it := new.ElementIterator(); it.Next(); {
+				_, val := it.Element()
+				newItems = append(newItems, val)
+			}
+		}
+
+		null := ctyNullBlockVal(schema)
+		for _, oldItem := range oldItems {
+			if ctyValsContains(newItems, oldItem) {
+				continue
+			}
+			bc, err := blockChangeJSONFor(schema, "", oldItem, null)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, bc)
+		}
+		for _, newItem := range newItems {
+			if ctyValsContains(oldItems, newItem) {
+				continue
+			}
+			bc, err := blockChangeJSONFor(schema, "", null, newItem)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, bc)
+		}
+	}
+
+	return ret, nil
+}
+
+func blockChangeJSONFor(schema *configschema.Block, key string, old, new cty.Value) (*BlockChangeJSON, error) {
+	attrs, err := attributeChangesJSON(schema, old, new)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := blockChangesJSON(schema, old, new)
+	if err != nil {
+		return nil, err
+	}
+
+	var action string
+	switch {
+	case old.IsNull():
+		action = diffs.Create.String()
+	case new.IsNull():
+		action = diffs.Delete.String()
+	default:
+		action = diffs.Update.String()
+	}
+
+	return &BlockChangeJSON{
+		Key:        key,
+		Action:     action,
+		Attributes: attrs,
+		Blocks:     blocks,
+	}, nil
+}
+
+func ctyValsContains(haystack []cty.Value, needle cty.Value) bool {
+	for _, v := range haystack {
+		if v.RawEquals(needle) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package format
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/diffs"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TestResourceChangeJSON_golden runs a collection of fixtures through both
+// ResourceChange and ResourceChangeJSON and checks that they stay in
+// lockstep: every top-level attribute mentioned in the JSON output must
+// also appear somewhere in the text rendering. It also compares the
+// JSON output (with the address field normalized away, since its exact
+// formatting is owned by terraform.ResourceAddress) against a golden file
+// for each fixture.
+func TestResourceChangeJSON_golden(t *testing.T) {
+	tests := map[string]struct {
+		Action diffs.Action
+		Old    cty.Value
+		New    cty.Value
+		Schema *configschema.Block
+	}{
+		"update-string": {
+			diffs.Update,
+			cty.ObjectVal(map[string]cty.Value{
+				"ami": cty.StringVal("ami-AAA"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"ami": cty.StringVal("ami-BBB"),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+		"create": {
+			diffs.Create,
+			cty.NullVal(cty.Object(map[string]cty.Type{"ami": cty.String})),
+			cty.ObjectVal(map[string]cty.Value{
+				"ami": cty.StringVal("ami-AAA"),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+		"delete": {
+			diffs.Delete,
+			cty.ObjectVal(map[string]cty.Value{
+				"ami": cty.StringVal("ami-AAA"),
+			}),
+			cty.NullVal(cty.Object(map[string]cty.Type{"ami": cty.String})),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+		"sensitive": {
+			diffs.Update,
+			cty.ObjectVal(map[string]cty.Value{
+				"password": cty.StringVal("old-secret"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"password": cty.StringVal("new-secret"),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"password": {Type: cty.String, Optional: true, Sensitive: true},
+				},
+			},
+		},
+		"unknown": {
+			diffs.Create,
+			cty.NullVal(cty.Object(map[string]cty.Type{"id": cty.String})),
+			cty.ObjectVal(map[string]cty.Value{
+				"id": cty.UnknownVal(cty.String),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"id": {Type: cty.String, Computed: true},
+				},
+			},
+		},
+		"number": {
+			diffs.Update,
+			cty.ObjectVal(map[string]cty.Value{
+				"count": cty.NumberIntVal(1),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"count": cty.NumberIntVal(2),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"count": {Type: cty.Number, Optional: true},
+				},
+			},
+		},
+		"nested-block-list": {
+			diffs.Update,
+			cty.ObjectVal(map[string]cty.Value{
+				"ingress": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(80)}),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"ingress": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(80)}),
+					cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(443)}),
+				}),
+			}),
+			&configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"ingress": {
+						Nesting: configschema.NestingList,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"port": {Type: cty.Number, Optional: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := &terraform.ResourceAddress{
+				Mode: config.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "example",
+			}
+			change := &diffs.Change{
+				Action: test.Action,
+				Old:    test.Old,
+				New:    test.New,
+			}
+
+			text := ResourceChange(addr, change, test.Schema, nil)
+
+			got, err := ResourceChangeJSON(addr, change, test.Schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			for attrName := range got.Attributes {
+				if !strings.Contains(text, attrName) {
+					t.Errorf("attribute %q appears in JSON output but not in text output:\n%s", attrName, text)
+				}
+			}
+
+			raw, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("failed to marshal JSON: %s", err)
+			}
+			var generic map[string]interface{}
+			if err := json.Unmarshal(raw, &generic); err != nil {
+				t.Fatalf("failed to unmarshal JSON: %s", err)
+			}
+			delete(generic, "address") // formatting of this is owned by terraform.ResourceAddress, not tested here
+
+			normalized, err := json.MarshalIndent(generic, "", "  ")
+			if err != nil {
+				t.Fatalf("failed to re-marshal JSON: %s", err)
+			}
+
+			wantPath := filepath.Join("testdata", "diff-json", name+".json")
+			want, err := ioutil.ReadFile(wantPath)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %s", err)
+			}
+
+			if got, want := strings.TrimRight(string(normalized), "\n"), strings.TrimRight(string(want), "\n"); got != want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/diffs"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestResourceChange_refresh(t *testing.T) {
+	tests := map[string]struct {
+		Old, New cty.Value
+		Schema   *configschema.Block
+		Want     string
+	}{
+		"refreshed primitive attribute": {
+			cty.ObjectVal(map[string]cty.Value{
+				"id":  cty.StringVal("i-02ae66f368e8518a9"),
+				"ami": cty.StringVal("ami-AAA"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"id":  cty.StringVal("i-02ae66f368e8518a9"),
+				"ami": cty.StringVal("ami-BBB"),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"id":  {Type: cty.String, Optional: true, Computed: true},
+					"ami": {Type: cty.String, Optional: true},
+				},
+			},
+			` ~> resource "test_instance" "example" { # refreshed from real infrastructure
+      ~ ami = "ami-AAA" -> "ami-BBB" # drifted from state
+    }`,
+		},
+		"refreshed number attribute": {
+			cty.ObjectVal(map[string]cty.Value{
+				"count": cty.NumberIntVal(1),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"count": cty.NumberIntVal(2),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"count": {Type: cty.Number, Optional: true, Computed: true},
+				},
+			},
+			` ~> resource "test_instance" "example" { # refreshed from real infrastructure
+      ~ count = 1 -> 2 # drifted from state
+    }`,
+		},
+		"refreshed list attribute": {
+			cty.ObjectVal(map[string]cty.Value{
+				"list": cty.ListVal([]cty.Value{
+					cty.StringVal("a"),
+					cty.StringVal("b"),
+					cty.StringVal("c"),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"list": cty.ListVal([]cty.Value{
+					cty.StringVal("a"),
+					cty.StringVal("x"),
+					cty.StringVal("b"),
+					cty.StringVal("c"),
+				}),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"list": {Type: cty.List(cty.String), Optional: true, Computed: true},
+				},
+			},
+			` ~> resource "test_instance" "example" { # refreshed from real infrastructure
+      ~ list = [
+            "a",
+          + "x",
+            "b",
+            "c",
+        ] # drifted from state
+    }`,
+		},
+		"refreshed map attribute": {
+			cty.ObjectVal(map[string]cty.Value{
+				"tags": cty.MapVal(map[string]cty.Value{
+					"a": cty.StringVal("1"),
+					"b": cty.StringVal("2"),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"tags": cty.MapVal(map[string]cty.Value{
+					"a": cty.StringVal("1"),
+					"b": cty.StringVal("3"),
+				}),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"tags": {Type: cty.Map(cty.String), Optional: true, Computed: true},
+				},
+			},
+			` ~> resource "test_instance" "example" { # refreshed from real infrastructure
+      ~ tags = {
+            "a" = "1"
+          ~ "b" = "2" -> "3"
+        } # drifted from state
+    }`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := &terraform.ResourceAddress{
+				Mode: config.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "example",
+			}
+			change := &diffs.Change{
+				Action: diffs.Refresh,
+				Old:    test.Old,
+				New:    test.New,
+			}
+
+			got := ResourceChange(addr, change, test.Schema, nil)
+			got = strings.TrimSuffix(got, "\n")
+			if got != test.Want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestResourceChange_nestedBlocks(t *testing.T) {
+	tests := map[string]struct {
+		Old, New cty.Value
+		Schema   *configschema.Block
+		Want     string
+	}{
+		"NestingSingle": {
+			cty.ObjectVal(map[string]cty.Value{
+				"network_interface": cty.ObjectVal(map[string]cty.Value{
+					"device_index": cty.NumberIntVal(0),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"network_interface": cty.ObjectVal(map[string]cty.Value{
+					"device_index": cty.NumberIntVal(1),
+				}),
+			}),
+			&configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"network_interface": {
+						Nesting: configschema.NestingSingle,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"device_index": {Type: cty.Number, Optional: true},
+							},
+						},
+					},
+				},
+			},
+			`  ~ resource "test_instance" "example" {
+      ~ network_interface {
+          ~ device_index = 0 -> 1
+      }
+    }`,
+		},
+		"NestingList": {
+			cty.ObjectVal(map[string]cty.Value{
+				"ingress": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(80)}),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"ingress": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(80)}),
+					cty.ObjectVal(map[string]cty.Value{"port": cty.NumberIntVal(443)}),
+				}),
+			}),
+			&configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"ingress": {
+						Nesting: configschema.NestingList,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"port": {Type: cty.Number, Optional: true},
+							},
+						},
+					},
+				},
+			},
+			`  ~ resource "test_instance" "example" {
+      + ingress {
+          + port = 443
+      }
+    }`,
+		},
+		"NestingMap": {
+			cty.ObjectVal(map[string]cty.Value{
+				"tag": cty.MapVal(map[string]cty.Value{
+					"a": cty.ObjectVal(map[string]cty.Value{"value": cty.StringVal("1")}),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"tag": cty.MapVal(map[string]cty.Value{
+					"a": cty.ObjectVal(map[string]cty.Value{"value": cty.StringVal("2")}),
+					"b": cty.ObjectVal(map[string]cty.Value{"value": cty.StringVal("3")}),
+				}),
+			}),
+			&configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"tag": {
+						Nesting: configschema.NestingMap,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"value": {Type: cty.String, Optional: true},
+							},
+						},
+					},
+				},
+			},
+			`  ~ resource "test_instance" "example" {
+      ~ tag "a" {
+          ~ value = "1" -> "2"
+      }
+      + tag "b" {
+          + value = "3"
+      }
+    }`,
+		},
+		"NestingSet": {
+			cty.ObjectVal(map[string]cty.Value{
+				"rule": cty.SetVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"cidr": cty.StringVal("10.0.0.0/8")}),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"rule": cty.SetVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"cidr": cty.StringVal("10.0.0.0/8")}),
+					cty.ObjectVal(map[string]cty.Value{"cidr": cty.StringVal("192.168.0.0/16")}),
+				}),
+			}),
+			&configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"rule": {
+						Nesting: configschema.NestingSet,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"cidr": {Type: cty.String, Optional: true},
+							},
+						},
+					},
+				},
+			},
+			`  ~ resource "test_instance" "example" {
+      + rule {
+          + cidr = "192.168.0.0/16"
+      }
+    }`,
+		},
+		"mixed attributes and blocks": {
+			cty.ObjectVal(map[string]cty.Value{
+				"ami": cty.StringVal("ami-1"),
+				"ebs_block_device": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"volume_size": cty.NumberIntVal(8)}),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"ami": cty.StringVal("ami-2"),
+				"ebs_block_device": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"volume_size": cty.NumberIntVal(8)}),
+					cty.ObjectVal(map[string]cty.Value{"volume_size": cty.NumberIntVal(20)}),
+				}),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Optional: true},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"ebs_block_device": {
+						Nesting: configschema.NestingList,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"volume_size": {Type: cty.Number, Optional: true},
+							},
+						},
+					},
+				},
+			},
+			`  ~ resource "test_instance" "example" {
+      ~ ami = "ami-1" -> "ami-2"
+      + ebs_block_device {
+          + volume_size = 20
+      }
+    }`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := &terraform.ResourceAddress{
+				Mode: config.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "example",
+			}
+			change := &diffs.Change{
+				Action: diffs.Update,
+				Old:    test.Old,
+				New:    test.New,
+			}
+
+			got := ResourceChange(addr, change, test.Schema, nil)
+			got = strings.TrimSuffix(got, "\n")
+			if got != test.Want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestResourceChange_mapsAndLists(t *testing.T) {
+	tests := map[string]struct {
+		Old, New cty.Value
+		Schema   *configschema.Block
+		Want     string
+	}{
+		"list insertion in the middle": {
+			cty.ObjectVal(map[string]cty.Value{
+				"list": cty.ListVal([]cty.Value{
+					cty.StringVal("a"),
+					cty.StringVal("b"),
+					cty.StringVal("c"),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"list": cty.ListVal([]cty.Value{
+					cty.StringVal("a"),
+					cty.StringVal("x"),
+					cty.StringVal("b"),
+					cty.StringVal("c"),
+				}),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"list": {Type: cty.List(cty.String), Optional: true},
+				},
+			},
+			`  ~ resource "test_instance" "example" {
+      ~ list = [
+            "a",
+          + "x",
+            "b",
+            "c",
+        ]
+    }`,
+		},
+		"map per-key diff with nested lists": {
+			cty.ObjectVal(map[string]cty.Value{
+				"data": cty.MapVal(map[string]cty.Value{
+					"x": cty.ListVal([]cty.Value{cty.StringVal("1")}),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"data": cty.MapVal(map[string]cty.Value{
+					"x": cty.ListVal([]cty.Value{cty.StringVal("1"), cty.StringVal("2")}),
+					"y": cty.ListVal([]cty.Value{cty.StringVal("3")}),
+				}),
+			}),
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"data": {Type: cty.Map(cty.List(cty.String)), Optional: true},
+				},
+			},
+			`  ~ resource "test_instance" "example" {
+      ~ data = {
+          ~ "x" = [
+                "1",
+              + "2",
+            ]
+          + "y" = [
+                "3",
+            ]
+        }
+    }`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := &terraform.ResourceAddress{
+				Mode: config.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "example",
+			}
+			change := &diffs.Change{
+				Action: diffs.Update,
+				Old:    test.Old,
+				New:    test.New,
+			}
+
+			got := ResourceChange(addr, change, test.Schema, nil)
+			got = strings.TrimSuffix(got, "\n")
+			if got != test.Want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestResourceChangeHeader(t *testing.T) {
+	addr := &terraform.ResourceAddress{
+		Mode: config.ManagedResourceMode,
+		Type: "test_instance",
+		Name: "example",
+	}
+	change := &diffs.Change{
+		Action: diffs.Replace,
+		Old: cty.ObjectVal(map[string]cty.Value{
+			"ami": cty.StringVal("ami-AAA"),
+		}),
+		New: cty.ObjectVal(map[string]cty.Value{
+			"ami": cty.StringVal("ami-BBB"),
+		}),
+	}
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami": {Type: cty.String, Optional: true},
+		},
+	}
+
+	header := ResourceChangeHeader(addr, change, nil)
+	want := `-/+ resource "test_instance" "example" { # new resource required
+`
+	if header != want {
+		t.Errorf("wrong header\ngot:  %q\nwant: %q", header, want)
+	}
+
+	full := ResourceChange(addr, change, schema, nil)
+	if !strings.HasPrefix(full, header) {
+		t.Errorf("ResourceChange does not start with ResourceChangeHeader's result\nfull:   %q\nheader: %q", full, header)
+	}
+}
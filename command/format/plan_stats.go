@@ -0,0 +1,72 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/terraform/diffs"
+	"github.com/mitchellh/colorstring"
+)
+
+// PlanChangeStats is a summary of how many resource instance changes of
+// each diffs.Action are present in a plan, for use in progress output and
+// headers without requiring the caller to walk the full list of changes
+// itself.
+type PlanChangeStats struct {
+	Create  int
+	Read    int
+	Update  int
+	Replace int
+	Delete  int
+	Refresh int
+}
+
+// PlanStats counts the changes in the given slice by their action, for use
+// with PlanSummary.
+func PlanStats(changes []*diffs.Change) PlanChangeStats {
+	var stats PlanChangeStats
+	for _, change := range changes {
+		switch change.Action {
+		case diffs.Create:
+			stats.Create++
+		case diffs.Read:
+			stats.Read++
+		case diffs.Update:
+			stats.Update++
+		case diffs.Replace:
+			stats.Replace++
+		case diffs.Delete:
+			stats.Delete++
+		case diffs.Refresh:
+			stats.Refresh++
+		}
+	}
+	return stats
+}
+
+// PlanSummary renders the familiar "Plan: X to add, Y to change, Z to
+// destroy." line, plus a separate "N to refresh." clause when the plan
+// includes any refreshed resources.
+//
+// A replace is counted as both one add and one destroy, matching how the
+// two symbols in its action indicator ("-/+") are read.
+//
+// If "color" is non-nil, it will be used to color the result. Otherwise,
+// no color codes will be included.
+func PlanSummary(stats PlanChangeStats, color *colorstring.Colorize) string {
+	color = normalizeColor(color)
+
+	var buf bytes.Buffer
+	buf.WriteString(color.Color(fmt.Sprintf(
+		"[bold]Plan:[reset] %d to add, %d to change, %d to destroy.",
+		stats.Create+stats.Replace,
+		stats.Update,
+		stats.Delete+stats.Replace,
+	)))
+
+	if stats.Refresh > 0 {
+		buf.WriteString(color.Color(fmt.Sprintf(" %d to refresh.", stats.Refresh)))
+	}
+
+	return buf.String()
+}
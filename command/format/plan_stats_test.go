@@ -0,0 +1,61 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/diffs"
+)
+
+func TestPlanStats(t *testing.T) {
+	changes := []*diffs.Change{
+		{Action: diffs.Create},
+		{Action: diffs.Create},
+		{Action: diffs.Update},
+		{Action: diffs.Replace},
+		{Action: diffs.Delete},
+		{Action: diffs.Refresh},
+		{Action: diffs.Refresh},
+		{Action: diffs.Refresh},
+	}
+
+	got := PlanStats(changes)
+	want := PlanChangeStats{
+		Create:  2,
+		Update:  1,
+		Replace: 1,
+		Delete:  1,
+		Refresh: 3,
+	}
+	if got != want {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestPlanSummary(t *testing.T) {
+	stats := PlanChangeStats{
+		Create:  2,
+		Update:  1,
+		Replace: 1,
+		Delete:  1,
+		Refresh: 3,
+	}
+
+	got := PlanSummary(stats, nil)
+	want := "Plan: 3 to add, 1 to change, 2 to destroy. 3 to refresh."
+	if got != want {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestPlanSummary_noRefresh(t *testing.T) {
+	stats := PlanChangeStats{
+		Create: 1,
+		Delete: 1,
+	}
+
+	got := PlanSummary(stats, nil)
+	want := "Plan: 1 to add, 0 to change, 1 to destroy."
+	if got != want {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package diffs
+
+// Action represents the type of change being made to a particular resource
+// instance as part of a plan.
+type Action rune
+
+const (
+	// NoOp indicates that no change is to be made.
+	NoOp Action = 0
+
+	// Create indicates that a new resource instance is to be created.
+	Create Action = '+'
+
+	// Read indicates that an existing data resource is to be read.
+	Read Action = '←'
+
+	// Update indicates that an existing resource instance's arguments are
+	// to be updated in place, without replacing the underlying remote
+	// object.
+	Update Action = '~'
+
+	// Replace indicates that an existing resource instance is to be
+	// destroyed and a new one created in its place. Whether the destroy
+	// happens before or after the create is a decision made elsewhere;
+	// this action alone does not distinguish between the two orderings.
+	Replace Action = '±'
+
+	// Delete indicates that an existing resource instance is to be
+	// destroyed, with nothing created in its place.
+	Delete Action = '-'
+
+	// Refresh indicates that a resource instance's state is to be
+	// reconciled against the value read directly from the provider,
+	// without any corresponding configuration change. A Refresh change
+	// compares the prior state to a freshly-read value rather than to a
+	// planned configuration, so callers that render Refresh changes must
+	// not apply input-oriented heuristics (such as "requires replacement")
+	// that assume the new value came from configuration.
+	Refresh Action = '↻'
+)
+
+// String returns a short machine-readable name for the action, suitable for
+// use as a stable identifier in serialized output such as JSON.
+func (a Action) String() string {
+	switch a {
+	case NoOp:
+		return "no-op"
+	case Create:
+		return "create"
+	case Read:
+		return "read"
+	case Update:
+		return "update"
+	case Replace:
+		return "replace"
+	case Delete:
+		return "delete"
+	case Refresh:
+		return "refresh"
+	default:
+		return "invalid"
+	}
+}
@@ -0,0 +1,20 @@
+package diffs
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Change describes a change to a particular resource instance, as part of
+// a larger Plan.
+type Change struct {
+	// Action is the type of change being made.
+	Action Action
+
+	// Old and New are the values before and after the change, as
+	// appropriate for Action. For Create, Old is always a null value of
+	// the resource's type. For Delete, New is always a null value of the
+	// resource's type. For Refresh, Old is the prior state as recorded
+	// before this change and New is the value returned by the provider's
+	// Read operation, rather than a value derived from configuration.
+	Old, New cty.Value
+}
@@ -0,0 +1,53 @@
+package diffs
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LongestCommonSubsequence finds the longest common subsequence of elements
+// shared between old and new, using cty's RawEquals to decide whether two
+// elements are equal.
+//
+// The result is a subsequence of old (and, equivalently, of new) that
+// appears in both inputs in the same relative order. Callers typically use
+// this to walk old, new, and the LCS in lockstep in order to produce a
+// human-friendly diff: elements present only in old are shown as removed,
+// elements present only in new are shown as added, and elements present in
+// the LCS are shown as unchanged.
+func LongestCommonSubsequence(old, new []cty.Value) []cty.Value {
+	// Classic dynamic-programming LCS, using a table of lengths and then
+	// walking it backwards to recover the actual sequence.
+	lengths := make([][]int, len(old)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(new)+1)
+	}
+
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i].RawEquals(new[j]) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var result []cty.Value
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i].RawEquals(new[j]):
+			result = append(result, old[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return result
+}